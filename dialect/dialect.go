@@ -0,0 +1,38 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+// Package dialect holds the database dialects the migration engine knows
+// how to talk to, and the minimal interfaces the sql sub-packages implement
+// so the rest of the engine never depends on a concrete driver.
+package dialect
+
+import "context"
+
+// Dialect names understood throughout the engine. MariaDB is notably absent
+// from sql.Open's driver argument: it speaks the MySQL wire protocol and is
+// only ever identified after connecting, never chosen by the caller.
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite3"
+)
+
+// Driver is the subset of database/sql's *DB that the migration engine
+// needs: executing statements and reading rows, reporting which dialect it
+// speaks, opening transactions, and closing the underlying connection.
+type Driver interface {
+	Exec(ctx context.Context, query string, args, v interface{}) error
+	Query(ctx context.Context, query string, args, v interface{}) error
+	Dialect() string
+	Tx(ctx context.Context) (Tx, error)
+	Close() error
+}
+
+// Tx is a Driver bound to a single transaction, with the usual commit and
+// rollback to end it.
+type Tx interface {
+	Driver
+	Commit() error
+	Rollback() error
+}
@@ -0,0 +1,23 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package dialect
+
+import "strings"
+
+// MariaDB identifies connections to a MariaDB server. MariaDB speaks the
+// MySQL wire protocol and therefore shares its driver name ("mysql"), so
+// unlike MySQL, Postgres and SQLite it is never chosen by the caller at
+// sql.Open time. Instead, the driver detects it by inspecting the server
+// version string reported over the connection (e.g. "5.5.5-10.5.9-MariaDB")
+// and callers that need to special-case it should branch on this constant
+// exactly like they already do for MySQL.
+const MariaDB = "mariadb"
+
+// IsMariaDB reports whether serverVersion, as returned by "SELECT VERSION()"
+// or the version embedded in the initial handshake packet, identifies a
+// MariaDB server rather than a genuine MySQL one.
+func IsMariaDB(serverVersion string) bool {
+	return strings.Contains(strings.ToLower(serverVersion), "mariadb")
+}
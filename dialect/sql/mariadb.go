@@ -0,0 +1,28 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/facebook/ent/dialect"
+)
+
+// detectMariaDB runs right after Open connects a "mysql" driver and reports
+// the dialect that should be stored on the returned *Driver. MariaDB shares
+// MySQL's driver name, so the only way to tell the two apart is to ask the
+// server. Open calls this for every "mysql" connection and keeps dialect.MySQL
+// whenever the query fails or the version string doesn't look like MariaDB's.
+func detectMariaDB(ctx context.Context, drv *Driver) string {
+	var version string
+	row := drv.QueryRowContext(ctx, "SELECT VERSION()")
+	if err := row.Scan(&version); err != nil {
+		return dialect.MySQL
+	}
+	if dialect.IsMariaDB(version) {
+		return dialect.MariaDB
+	}
+	return dialect.MySQL
+}
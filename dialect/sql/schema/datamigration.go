@@ -0,0 +1,51 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package schema
+
+import (
+	"context"
+
+	"github.com/facebook/ent/dialect"
+	"github.com/facebook/ent/dialect/sql"
+)
+
+// DataMigration is a named, idempotent callback that backfills data once a
+// schema version's DDL step has been applied; it does not run inside the
+// transaction of that DDL step. Generated schema packages keep their own
+// registry of these, keyed by the generated schema they belong to; this
+// package only provides the bookkeeping every such registry needs to track
+// which migrations have already run.
+type DataMigration struct {
+	Name string
+	Run  func(ctx context.Context, tx dialect.Tx) error
+}
+
+// EnsureDataMigrationsTable creates the schema_data_migrations table used to
+// track which named data migrations have already run, if it doesn't exist
+// yet.
+func EnsureDataMigrationsTable(ctx context.Context, tx dialect.Tx) error {
+	return tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_data_migrations (
+		name VARCHAR(255) NOT NULL PRIMARY KEY
+	)`, []interface{}{}, nil)
+}
+
+// DataMigrationDone reports whether the named data migration has already
+// been recorded as applied.
+func DataMigrationDone(ctx context.Context, tx dialect.Tx, name string) (bool, error) {
+	rows := &sql.Rows{}
+	q := "SELECT 1 FROM schema_data_migrations WHERE name = " + Placeholder(tx.Dialect(), 1)
+	if err := tx.Query(ctx, q, []interface{}{name}, rows); err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	return rows.Next(), rows.Err()
+}
+
+// MarkDataMigrationDone records the named data migration as applied, so it
+// is skipped on every subsequent run.
+func MarkDataMigrationDone(ctx context.Context, tx dialect.Tx, name string) error {
+	q := "INSERT INTO schema_data_migrations (name) VALUES (" + Placeholder(tx.Dialect(), 1) + ")"
+	return tx.Exec(ctx, q, []interface{}{name}, nil)
+}
@@ -0,0 +1,101 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// These helpers cover the handful of places MariaDB's DDL diverges from
+// MySQL's: JSON storage, the uuid column type, sequence support, the RENAME
+// COLUMN syntax and column collation queries. Of these, only the JSON rewrite
+// (mariaDBize) is applied by the engine, and only to statements run through
+// Diff's recording driver (Diff, Plan and WriteMigration) while the connected
+// dialect is dialect.MariaDB; the uuid, sequence, RENAME COLUMN and collation
+// helpers are not called by the engine.
+
+// mariaDBJSONType returns the column type (and accompanying CHECK
+// constraint) used to store JSON values on MariaDB, which has no dedicated
+// JSON type and instead stores it as LONGTEXT validated by JSON_VALID.
+func mariaDBJSONType(column string) (typ, check string) {
+	return "longtext", fmt.Sprintf("CHECK (JSON_VALID(`%s`))", column)
+}
+
+// mariaDBUUIDSupported reports whether the connected server has a native
+// uuid column type, introduced in MariaDB 10.7.
+func mariaDBUUIDSupported(version string) bool {
+	return compareVersion(version, "10.7") >= 0
+}
+
+// mariaDBSequenceSupported reports whether the connected server supports SQL
+// sequences (CREATE SEQUENCE), added in MariaDB 10.3.
+func mariaDBSequenceSupported(version string) bool {
+	return compareVersion(version, "10.3") >= 0
+}
+
+// mariaDBRenameColumn returns the statement used to rename a column on
+// MariaDB. Versions before 10.5.2 lack the standard RENAME COLUMN syntax and
+// need the legacy CHANGE form, which also requires restating the type.
+func mariaDBRenameColumn(version, table, old, new, columnType string) string {
+	if compareVersion(version, "10.5.2") >= 0 {
+		return fmt.Sprintf("ALTER TABLE `%s` RENAME COLUMN `%s` TO `%s`", table, old, new)
+	}
+	return fmt.Sprintf("ALTER TABLE `%s` CHANGE `%s` `%s` %s", table, old, new, columnType)
+}
+
+// mariaDBCollationQuery returns the information_schema query used to read a
+// table's column collations. It's the same query MySQL uses, but callers
+// must not assume MySQL's defaults when comparing the result: MariaDB ships
+// its own default collation set (e.g. "utf8mb4_general_ci" rather than MySQL
+// 8's "utf8mb4_0900_ai_ci").
+func mariaDBCollationQuery(schema, table string) string {
+	return fmt.Sprintf(
+		"SELECT `COLUMN_NAME`, `COLLATION_NAME` FROM `INFORMATION_SCHEMA`.`COLUMNS` "+
+			"WHERE `TABLE_SCHEMA` = '%s' AND `TABLE_NAME` = '%s'", schema, table,
+	)
+}
+
+var jsonColumnRE = regexp.MustCompile("(?i)`([a-zA-Z0-9_]+)`\\s+JSON\\b")
+
+// mariaDBize rewrites a statement the engine is about to run against a
+// MariaDB connection, substituting the one column type it can't reuse from
+// the MySQL branch as-is: a generic JSON column declaration becomes the
+// LONGTEXT + CHECK(JSON_VALID(...)) form mariaDBJSONType returns. Every
+// other statement passes through unchanged.
+func mariaDBize(query string) string {
+	loc := jsonColumnRE.FindStringSubmatchIndex(query)
+	if loc == nil {
+		return query
+	}
+	column := query[loc[2]:loc[3]]
+	typ, check := mariaDBJSONType(column)
+	replacement := fmt.Sprintf("`%s` %s %s", column, typ, check)
+	return query[:loc[0]] + replacement + query[loc[1]:]
+}
+
+// compareVersion compares two dotted version strings component by component
+// and returns -1, 0 or 1, the same way strings.Compare does.
+func compareVersion(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var av, bv int
+		if i < len(as) {
+			av, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			bv, _ = strconv.Atoi(bs[i])
+		}
+		if av != bv {
+			if av < bv {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareVersion(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"10.5.2", "10.5.2", 0},
+		{"10.5", "10.5.2", -1},
+		{"10.7.1", "10.5.9", 1},
+		{"5.5.5", "10.3", -1},
+	}
+	for _, c := range cases {
+		if got := compareVersion(c.a, c.b); got != c.want {
+			t.Errorf("compareVersion(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestMariaDBVersionGates(t *testing.T) {
+	if mariaDBUUIDSupported("10.6.9") {
+		t.Error("uuid should not be supported before 10.7")
+	}
+	if !mariaDBUUIDSupported("10.7.0") {
+		t.Error("uuid should be supported from 10.7 onward")
+	}
+	if mariaDBSequenceSupported("10.2.9") {
+		t.Error("sequences should not be supported before 10.3")
+	}
+	if !mariaDBSequenceSupported("10.3.0") {
+		t.Error("sequences should be supported from 10.3 onward")
+	}
+}
+
+func TestMariaDBRenameColumn(t *testing.T) {
+	if got, want := mariaDBRenameColumn("10.5.9", "users", "old", "new", "varchar(32)"), "ALTER TABLE `users` RENAME COLUMN `old` TO `new`"; got != want {
+		t.Errorf("mariaDBRenameColumn(10.5.9) = %q, want %q", got, want)
+	}
+	if got, want := mariaDBRenameColumn("10.3.0", "users", "old", "new", "varchar(32)"), "ALTER TABLE `users` CHANGE `old` `new` varchar(32)"; got != want {
+		t.Errorf("mariaDBRenameColumn(10.3.0) = %q, want %q", got, want)
+	}
+}
+
+func TestMariaDBJSONType(t *testing.T) {
+	typ, check := mariaDBJSONType("meta")
+	if typ != "longtext" {
+		t.Errorf("mariaDBJSONType typ = %q, want longtext", typ)
+	}
+	if check != "CHECK (JSON_VALID(`meta`))" {
+		t.Errorf("mariaDBJSONType check = %q", check)
+	}
+}
+
+func TestMariaDBCollationQuery(t *testing.T) {
+	q := mariaDBCollationQuery("migrate", "users")
+	if !strings.Contains(q, "migrate") || !strings.Contains(q, "users") {
+		t.Errorf("mariaDBCollationQuery(%q, %q) = %q, missing schema/table", "migrate", "users", q)
+	}
+}
+
+func TestMariaDBize(t *testing.T) {
+	in := "CREATE TABLE `widgets` (`id` INTEGER PRIMARY KEY, `meta` JSON)"
+	out := mariaDBize(in)
+	if strings.Contains(out, " JSON)") {
+		t.Errorf("mariaDBize did not rewrite the JSON column: %q", out)
+	}
+	if !strings.Contains(out, "longtext") || !strings.Contains(out, "JSON_VALID") {
+		t.Errorf("mariaDBize(%q) = %q, missing longtext/JSON_VALID", in, out)
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package schema
+
+import (
+	"fmt"
+
+	"github.com/facebook/ent/dialect"
+)
+
+// Placeholder returns the positional argument placeholder for the n'th
+// (1-indexed) bind argument in dialectName's flavor of SQL: Postgres spells
+// it "$n", every other dialect this engine supports uses "?". Exported so
+// callers outside this package - generated schema code and its data
+// migration callbacks - can build dialect-correct SQL too.
+func Placeholder(dialectName string, n int) string {
+	if dialectName == dialect.Postgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
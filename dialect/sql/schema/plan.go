@@ -0,0 +1,264 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/facebook/ent/dialect"
+)
+
+// Severity classifies how risky a Change is to apply against a live
+// database.
+type Severity int
+
+const (
+	// SeveritySafe changes can run without locking or losing data, e.g.
+	// CREATE TABLE or ADD COLUMN.
+	SeveritySafe Severity = iota
+	// SeverityLocking changes require a lock that can block writers while
+	// they run, e.g. adding an index on some dialects.
+	SeverityLocking
+	// SeverityDestructive changes can lose data: dropping a column or an
+	// index, which Create only does when opted into via WithDropColumn or
+	// WithDropIndex, any other DROP statement, and a column-type change run
+	// through a TypeConverter.
+	SeverityDestructive
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeveritySafe:
+		return "safe"
+	case SeverityLocking:
+		return "requires-lock"
+	case SeverityDestructive:
+		return "destructive"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a single DDL statement the migration engine would execute to
+// bring the database in line with the desired schema, along with the
+// statement that would undo it and how risky it is to run.
+type Change struct {
+	Table    string
+	Stmt     string
+	Down     string
+	Severity Severity
+}
+
+// MigrateOptions holds the destructive operations Create only performs when
+// explicitly opted into.
+type MigrateOptions struct {
+	DropColumn bool
+	DropIndex  bool
+}
+
+// MigrateOption configures a Create (and, since Diff/Plan run against the
+// same create callback, a Diff/Plan) call.
+type MigrateOption func(*MigrateOptions)
+
+// WithDropColumn opts into dropping columns that are no longer part of the
+// schema being migrated to.
+func WithDropColumn(b bool) MigrateOption {
+	return func(o *MigrateOptions) { o.DropColumn = b }
+}
+
+// WithDropIndex opts into dropping indexes that are no longer part of the
+// schema being migrated to.
+func WithDropIndex(b bool) MigrateOption {
+	return func(o *MigrateOptions) { o.DropIndex = b }
+}
+
+// CreateFunc is the shape of a generated Schema.Create: it applies whatever
+// DDL changes opts call for against drv. Diff and Plan run it against a
+// recording driver instead of drv directly so they can observe the changes
+// without executing them for real.
+type CreateFunc func(ctx context.Context, drv dialect.Driver, opts ...MigrateOption) error
+
+// Diff reports the set of changes create would apply to the database behind
+// drv for the given opts, without executing them: it runs create against a
+// driver that records every DDL statement classify recognizes, inside a
+// transaction that is always rolled back, so nothing is ever committed.
+func Diff(ctx context.Context, drv dialect.Driver, create CreateFunc, opts ...MigrateOption) ([]Change, error) {
+	tx, err := drv.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(tx)
+	var changes []Change
+	rec := &recordingDriver{tx: tx, changes: &changes}
+	if err := create(ctx, rec, opts...); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Plan writes the DDL that create would run against drv for the given opts,
+// grouped by table and annotated with its severity, without executing it -
+// giving operators a way to review a migration, destructive changes
+// included, in code review and CI before it touches production.
+func Plan(ctx context.Context, drv dialect.Driver, w io.Writer, create CreateFunc, opts ...MigrateOption) error {
+	changes, err := Diff(ctx, drv, create, opts...)
+	if err != nil {
+		return err
+	}
+	var table string
+	for _, c := range changes {
+		if c.Table != table {
+			if _, err := fmt.Fprintf(w, "-- table: %s\n", c.Table); err != nil {
+				return err
+			}
+			table = c.Table
+		}
+		if _, err := fmt.Fprintf(w, "-- %s\n%s;\n", c.Severity, c.Stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordingDriver wraps an already-open transaction, executing statements
+// against it as usual while recording every one classify recognizes as a
+// Change; other statements (INSERT, UPDATE, ...) are run but not recorded. The
+// transaction itself is rolled back by the caller of Diff, so nothing it
+// runs is ever persisted.
+type recordingDriver struct {
+	tx      dialect.Tx
+	changes *[]Change
+}
+
+func (r *recordingDriver) Exec(ctx context.Context, query string, args, v interface{}) error {
+	if c, ok := columnTypeChangeFrom(ctx); ok && c != nil {
+		*r.changes = append(*r.changes, Change{
+			Table:    c.table,
+			Stmt:     query,
+			Severity: SeverityDestructive,
+		})
+		// Strip the annotation before delegating to ConvertColumn, which
+		// calls back into r.Exec for each statement it runs.
+		return ConvertColumn(context.WithValue(ctx, columnTypeChangeKey{}, (*columnTypeChange)(nil)), r, r.tx.Dialect(), c.table, c.column, c.from, c.to)
+	}
+	if r.tx.Dialect() == dialect.MariaDB {
+		query = mariaDBize(query)
+	}
+	if table, severity, ok := classify(query); ok {
+		*r.changes = append(*r.changes, Change{
+			Table:    table,
+			Stmt:     query,
+			Down:     downStmt(query),
+			Severity: severity,
+		})
+	}
+	return r.tx.Exec(ctx, query, args, v)
+}
+
+func (r *recordingDriver) Query(ctx context.Context, query string, args, v interface{}) error {
+	return r.tx.Query(ctx, query, args, v)
+}
+
+func (r *recordingDriver) Dialect() string { return r.tx.Dialect() }
+func (r *recordingDriver) Close() error    { return nil }
+
+// Tx is called by nested Schema.Create implementations that open their own
+// transaction; since Diff already owns one, hand back the same recorder so
+// every statement funnels through the same rolled-back transaction.
+func (r *recordingDriver) Tx(context.Context) (dialect.Tx, error) {
+	return r, nil
+}
+
+func (r *recordingDriver) Commit() error   { return nil }
+func (r *recordingDriver) Rollback() error { return nil }
+
+// classify does a best-effort parse of a DDL statement to report which table
+// it touches and how risky it is to run. It only looks at the handful of
+// statement shapes the migration engine itself ever emits.
+func classify(query string) (table string, severity Severity, ok bool) {
+	fields := strings.Fields(query)
+	if len(fields) < 3 {
+		return "", 0, false
+	}
+	verb := strings.ToUpper(fields[0])
+	switch verb {
+	case "CREATE":
+		switch strings.ToUpper(fields[1]) {
+		case "TABLE":
+			return unquote(fields[2]), SeveritySafe, true
+		case "UNIQUE":
+			return tableFromOn(query), SeverityLocking, true
+		case "INDEX":
+			return tableFromOn(query), SeverityLocking, true
+		}
+	case "ALTER":
+		if strings.ToUpper(fields[1]) == "TABLE" {
+			table = unquote(fields[2])
+			switch {
+			case strings.Contains(strings.ToUpper(query), "DROP COLUMN"), strings.Contains(strings.ToUpper(query), "DROP INDEX"):
+				return table, SeverityDestructive, true
+			case strings.Contains(strings.ToUpper(query), "ADD COLUMN"), strings.Contains(strings.ToUpper(query), "RENAME COLUMN"):
+				return table, SeveritySafe, true
+			default:
+				return table, SeverityLocking, true
+			}
+		}
+	case "DROP":
+		return unquote(fields[2]), SeverityDestructive, true
+	}
+	return "", 0, false
+}
+
+func tableFromOn(query string) string {
+	if i := strings.Index(strings.ToUpper(query), " ON "); i >= 0 {
+		rest := strings.Fields(query[i+4:])
+		if len(rest) > 0 {
+			return unquote(strings.SplitN(rest[0], "(", 2)[0])
+		}
+	}
+	return ""
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, "`\"'();")
+}
+
+// downStmt is a best-effort inverse of query, used as the Down of every
+// recorded Change; it only handles CREATE TABLE/INDEX, which are the only
+// shapes the engine can unambiguously undo without extra bookkeeping.
+func downStmt(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) < 3 {
+		return ""
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "CREATE":
+		switch strings.ToUpper(fields[1]) {
+		case "TABLE":
+			return fmt.Sprintf("DROP TABLE %s", unquote(fields[2]))
+		case "INDEX", "UNIQUE":
+			name := indexName(fields)
+			if name == "" {
+				return ""
+			}
+			return fmt.Sprintf("DROP INDEX %s", name)
+		}
+	}
+	return ""
+}
+
+// indexName extracts the index identifier from a tokenized CREATE [UNIQUE]
+// INDEX statement, i.e. the token right before "ON".
+func indexName(fields []string) string {
+	for i, f := range fields {
+		if strings.ToUpper(f) == "ON" && i > 0 {
+			return unquote(fields[i-1])
+		}
+	}
+	return ""
+}
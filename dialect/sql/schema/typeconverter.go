@@ -0,0 +1,207 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/facebook/ent/dialect"
+	"github.com/facebook/ent/schema/field"
+)
+
+// ColumnType describes the type of a column being migrated, independent of
+// the dialect that eventually stores it. Raw carries the underlying SQL type
+// when a single field.Type maps to more than one column type across
+// dialects (e.g. field.TypeTime as "timestamp" vs "timestamptz").
+type ColumnType struct {
+	Type field.Type
+	Raw  string
+}
+
+// TypeConverter knows how to migrate a column from one incompatible type to
+// another - a pair the engine can't express as a plain ALTER COLUMN TYPE.
+type TypeConverter interface {
+	// CanConvert reports whether this converter handles the given pair.
+	CanConvert(from, to *ColumnType) bool
+	// SQL returns the statements used to convert column on table for the
+	// given dialect, run in order against separate Execs. Dialects that
+	// can't change a column's type in place (MySQL, MariaDB, SQLite) get
+	// the shadow-column + UPDATE + rename dance, several statements none
+	// of these drivers accept batched into one Exec; dialects that support
+	// ALTER ... TYPE ... USING return just the one.
+	SQL(dialect, table, column string) ([]string, error)
+}
+
+var typeConverters []TypeConverter
+
+// columnTypeChangeKey is the context key under which WithColumnTypeChange
+// stashes the column-type change a caller is about to apply.
+type columnTypeChangeKey struct{}
+
+type columnTypeChange struct {
+	table, column string
+	from, to      *ColumnType
+}
+
+// WithColumnTypeChange annotates ctx to say that the next statement run
+// against it is the incompatible column-type change for column on table,
+// from "from" to "to". recordingDriver.Exec, the driver Diff, Plan and
+// WriteMigration run create against, consults this before running the
+// statement verbatim: if present, it calls ConvertColumn instead, so the
+// dialect-appropriate conversion path runs rather than whatever raw ALTER
+// text the caller would otherwise emit. Statements run on any other driver
+// ignore the annotation.
+func WithColumnTypeChange(ctx context.Context, table, column string, from, to *ColumnType) context.Context {
+	return context.WithValue(ctx, columnTypeChangeKey{}, &columnTypeChange{table: table, column: column, from: from, to: to})
+}
+
+func columnTypeChangeFrom(ctx context.Context) (*columnTypeChange, bool) {
+	c, ok := ctx.Value(columnTypeChangeKey{}).(*columnTypeChange)
+	return c, ok
+}
+
+// RegisterTypeConverter registers a TypeConverter with the migration engine.
+// Converters are consulted in registration order; the first one whose
+// CanConvert reports true is used, so registering a new converter for a pair
+// an earlier one already handles shadows it.
+func RegisterTypeConverter(c TypeConverter) {
+	typeConverters = append(typeConverters, c)
+}
+
+// TypeConverterFor returns the first registered converter able to migrate a
+// column from "from" to "to", if any. When the migration engine detects an
+// incompatible column-type change and no converter is registered for it, it
+// must fail the migration rather than guess.
+func TypeConverterFor(from, to *ColumnType) (TypeConverter, bool) {
+	for _, c := range typeConverters {
+		if c.CanConvert(from, to) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// ConvertColumn is the hook recordingDriver.Exec calls for a statement whose
+// context carries a WithColumnTypeChange annotation, i.e. a column-type
+// change the caller can't express as a plain ALTER COLUMN TYPE; the engine
+// does not detect such changes itself. It looks up a registered converter for the pair and runs the statements it returns,
+// each in its own Exec, instead of emitting a conversion the database would
+// reject - most drivers reject a batch of statements handed to a single Exec.
+func ConvertColumn(ctx context.Context, drv dialect.Driver, dialectName, table, column string, from, to *ColumnType) error {
+	conv, ok := TypeConverterFor(from, to)
+	if !ok {
+		return fmt.Errorf("schema: no registered converter from %v to %v for column %q on table %q", from.Type, to.Type, column, table)
+	}
+	stmts, err := conv.SQL(dialectName, table, column)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if err := drv.Exec(ctx, stmt, []interface{}{}, nil); err != nil {
+			return fmt.Errorf("schema: converting column %q on table %q: %w", column, table, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterTypeConverter(numericToStringConverter{})
+	RegisterTypeConverter(stringToUUIDConverter{})
+	RegisterTypeConverter(textToJSONBConverter{})
+	RegisterTypeConverter(timestampToTimestamptzConverter{})
+}
+
+// numericToStringConverter converts any integer column to a string column,
+// the conversion already exercised by the Conversion entity in the migrate
+// integration tests.
+type numericToStringConverter struct{}
+
+func (numericToStringConverter) CanConvert(from, to *ColumnType) bool {
+	return isNumeric(from.Type) && to.Type == field.TypeString
+}
+
+func (numericToStringConverter) SQL(dialect, table, column string) ([]string, error) {
+	if dialect == "postgres" {
+		return []string{fmt.Sprintf(`ALTER TABLE %q ALTER COLUMN %q TYPE varchar USING %q::varchar`, table, column, column)}, nil
+	}
+	return shadowColumnSQL(dialect, table, column, "varchar(255)"), nil
+}
+
+func isNumeric(t field.Type) bool {
+	switch t {
+	case field.TypeInt8, field.TypeUint8, field.TypeInt16, field.TypeUint16,
+		field.TypeInt32, field.TypeUint32, field.TypeInt64, field.TypeUint64,
+		field.TypeInt, field.TypeUint:
+		return true
+	default:
+		return false
+	}
+}
+
+// stringToUUIDConverter converts a string column holding UUID-formatted
+// values to Postgres' native uuid type.
+type stringToUUIDConverter struct{}
+
+func (stringToUUIDConverter) CanConvert(from, to *ColumnType) bool {
+	return from.Type == field.TypeString && to.Type == field.TypeUUID
+}
+
+func (stringToUUIDConverter) SQL(dialect, table, column string) ([]string, error) {
+	if dialect != "postgres" {
+		return nil, fmt.Errorf("schema: string->uuid conversion is only supported on postgres")
+	}
+	return []string{fmt.Sprintf(`ALTER TABLE %q ALTER COLUMN %q TYPE uuid USING %q::uuid`, table, column, column)}, nil
+}
+
+// textToJSONBConverter converts a text column holding JSON-formatted values
+// to Postgres' native jsonb type.
+type textToJSONBConverter struct{}
+
+func (textToJSONBConverter) CanConvert(from, to *ColumnType) bool {
+	return from.Type == field.TypeText && to.Type == field.TypeJSON
+}
+
+func (textToJSONBConverter) SQL(dialect, table, column string) ([]string, error) {
+	if dialect != "postgres" {
+		return nil, fmt.Errorf("schema: text->jsonb conversion is only supported on postgres")
+	}
+	return []string{fmt.Sprintf(`ALTER TABLE %q ALTER COLUMN %q TYPE jsonb USING %q::jsonb`, table, column, column)}, nil
+}
+
+// timestampToTimestamptzConverter converts a naive timestamp column to
+// Postgres' timestamptz, assuming the existing values are already in UTC.
+type timestampToTimestamptzConverter struct{}
+
+func (timestampToTimestamptzConverter) CanConvert(from, to *ColumnType) bool {
+	return from.Type == field.TypeTime && from.Raw == "timestamp" && to.Raw == "timestamptz"
+}
+
+func (timestampToTimestamptzConverter) SQL(dialect, table, column string) ([]string, error) {
+	if dialect != "postgres" {
+		return nil, fmt.Errorf("schema: timestamptz conversion is only supported on postgres")
+	}
+	return []string{fmt.Sprintf(`ALTER TABLE %q ALTER COLUMN %q TYPE timestamptz USING %q AT TIME ZONE 'UTC'`, table, column, column)}, nil
+}
+
+// shadowColumnSQL implements the shadow-column + UPDATE + rename dance used
+// on dialects that can't change a column's type in place: add a new column
+// of the target type, backfill it from the old one, drop the old column and
+// rename the new one into its place - four separate statements, since no
+// database/sql driver accepts more than one statement in a single Exec.
+// SQLite has no CHANGE clause, so the rename uses RENAME COLUMN there.
+func shadowColumnSQL(dialect, table, column, newType string) []string {
+	shadow := column + "_new"
+	rename := fmt.Sprintf("ALTER TABLE `%s` CHANGE `%s` `%s` %s", table, shadow, column, newType)
+	if dialect == "sqlite3" {
+		rename = fmt.Sprintf("ALTER TABLE `%s` RENAME COLUMN `%s` TO `%s`", table, shadow, column)
+	}
+	return []string{
+		fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", table, shadow, newType),
+		fmt.Sprintf("UPDATE `%s` SET `%s` = `%s`", table, shadow, column),
+		fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN `%s`", table, column),
+		rename,
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package schema
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/facebook/ent/dialect"
+	"github.com/facebook/ent/dialect/sql"
+)
+
+// Revision is a single schema revision tracked in the schema_migrations
+// table: the highest version applied so far, whether it was left mid-way
+// through (dirty), and the checksum of the up-migration file that produced
+// it, used to detect files edited after the fact.
+type Revision struct {
+	Version  int
+	Dirty    bool
+	Checksum string
+}
+
+var migrationFileRE = regexp.MustCompile(`^(\d{4})_(.+)\.(up|down)\.sql$`)
+
+// WriteMigration diffs the schema create applies against the database behind
+// drv and writes the next numbered up/down SQL pair into dir (e.g.
+// "0002_add_phone.up.sql" / "0002_add_phone.down.sql"), named after name.
+// create is the caller's own Schema.Create, reused here exactly like Diff
+// and Plan reuse it, so the generated DDL never drifts from what Create
+// would apply directly, including the opts it was asked to apply.
+func WriteMigration(ctx context.Context, drv dialect.Driver, dir, name string, create CreateFunc, opts ...MigrateOption) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("schema: creating migrations dir: %w", err)
+	}
+	next, err := nextVersion(dir)
+	if err != nil {
+		return err
+	}
+	changes, err := Diff(ctx, drv, create, opts...)
+	if err != nil {
+		return err
+	}
+	var up, down strings.Builder
+	for _, c := range changes {
+		fmt.Fprintf(&up, "%s;\n", c.Stmt)
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].Down != "" {
+			fmt.Fprintf(&down, "%s;\n", changes[i].Down)
+		}
+	}
+	base := fmt.Sprintf("%04d_%s", next, name)
+	if err := os.WriteFile(filepath.Join(dir, base+".up.sql"), []byte(up.String()), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, base+".down.sql"), []byte(down.String()), 0o644)
+}
+
+func nextVersion(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 1, nil
+		}
+		return 0, err
+	}
+	max := 0
+	for _, e := range entries {
+		if m := migrationFileRE.FindStringSubmatch(e.Name()); m != nil {
+			if v, _ := strconv.Atoi(m[1]); v > max {
+				max = v
+			}
+		}
+	}
+	return max + 1, nil
+}
+
+// Migrate applies every pending up-migration found in dir, in order, each
+// inside its own transaction, and records it in the schema_migrations table.
+// Re-running Migrate once every revision has been applied is a no-op. It
+// refuses to run if the database is marked dirty, and fails loudly if any
+// file that was already applied - not just the most recent one - no longer
+// matches its recorded checksum.
+func Migrate(ctx context.Context, drv dialect.Driver, dir string) error {
+	if err := ensureMigrationsTable(ctx, drv); err != nil {
+		return err
+	}
+	rev, err := currentRevision(ctx, drv)
+	if err != nil {
+		return err
+	}
+	if rev.Dirty {
+		return fmt.Errorf("schema: database is dirty at version %d, fix it manually before migrating", rev.Version)
+	}
+	applied, err := appliedChecksums(ctx, drv)
+	if err != nil {
+		return err
+	}
+	ups, err := readMigrationFiles(dir, "up")
+	if err != nil {
+		return err
+	}
+	for _, f := range ups {
+		sum := checksum(f.body)
+		if f.version <= rev.Version {
+			if want, ok := applied[f.version]; ok && sum != want {
+				return fmt.Errorf("schema: checksum mismatch for version %d, the migration file was edited after being applied", f.version)
+			}
+			continue
+		}
+		if err := applyUp(ctx, drv, f.version, sum, f.body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback runs the last n applied down-migrations found in dir, most recent
+// first, each inside its own transaction.
+func Rollback(ctx context.Context, drv dialect.Driver, dir string, n int) error {
+	if err := ensureMigrationsTable(ctx, drv); err != nil {
+		return err
+	}
+	rev, err := currentRevision(ctx, drv)
+	if err != nil {
+		return err
+	}
+	if rev.Dirty {
+		return fmt.Errorf("schema: database is dirty at version %d, fix it manually before rolling back", rev.Version)
+	}
+	downs, err := readMigrationFiles(dir, "down")
+	if err != nil {
+		return err
+	}
+	sort.Slice(downs, func(i, j int) bool { return downs[i].version > downs[j].version })
+	for _, f := range downs {
+		if n <= 0 {
+			break
+		}
+		if f.version > rev.Version {
+			continue
+		}
+		if err := applyDown(ctx, drv, f.version, f.body); err != nil {
+			return err
+		}
+		n--
+	}
+	return nil
+}
+
+func applyUp(ctx context.Context, drv dialect.Driver, version int, sum, body string) error {
+	tx, err := drv.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	d := tx.Dialect()
+	insert := fmt.Sprintf("INSERT INTO schema_migrations (version, dirty, checksum) VALUES (%s, %s, %s)",
+		Placeholder(d, 1), Placeholder(d, 2), Placeholder(d, 3))
+	if err := tx.Exec(ctx, insert, []interface{}{version, true, sum}, nil); err != nil {
+		rollback(tx)
+		return err
+	}
+	for _, stmt := range splitStatements(body) {
+		if err := tx.Exec(ctx, stmt, []interface{}{}, nil); err != nil {
+			rollback(tx)
+			return fmt.Errorf("schema: applying version %d: %w", version, err)
+		}
+	}
+	update := fmt.Sprintf("UPDATE schema_migrations SET dirty = %s WHERE version = %s", Placeholder(d, 1), Placeholder(d, 2))
+	if err := tx.Exec(ctx, update, []interface{}{false, version}, nil); err != nil {
+		rollback(tx)
+		return err
+	}
+	return tx.Commit()
+}
+
+func applyDown(ctx context.Context, drv dialect.Driver, version int, body string) error {
+	tx, err := drv.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(body) {
+		if err := tx.Exec(ctx, stmt, []interface{}{}, nil); err != nil {
+			rollback(tx)
+			return fmt.Errorf("schema: rolling back version %d: %w", version, err)
+		}
+	}
+	del := fmt.Sprintf("DELETE FROM schema_migrations WHERE version = %s", Placeholder(tx.Dialect(), 1))
+	if err := tx.Exec(ctx, del, []interface{}{version}, nil); err != nil {
+		rollback(tx)
+		return err
+	}
+	return tx.Commit()
+}
+
+func rollback(tx dialect.Tx) {
+	_ = tx.Rollback()
+}
+
+func splitStatements(body string) []string {
+	var stmts []string
+	for _, s := range strings.Split(body, ";\n") {
+		if s = strings.TrimSpace(s); s != "" {
+			stmts = append(stmts, s)
+		}
+	}
+	return stmts
+}
+
+func ensureMigrationsTable(ctx context.Context, drv dialect.Driver) error {
+	return drv.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL DEFAULT 0,
+		checksum VARCHAR(64) NOT NULL
+	)`, []interface{}{}, nil)
+}
+
+func currentRevision(ctx context.Context, drv dialect.Driver) (Revision, error) {
+	rows := &sql.Rows{}
+	if err := drv.Query(ctx, "SELECT version, dirty, checksum FROM schema_migrations ORDER BY version DESC LIMIT 1", []interface{}{}, rows); err != nil {
+		return Revision{}, err
+	}
+	defer rows.Close()
+	var rev Revision
+	if rows.Next() {
+		if err := rows.Scan(&rev.Version, &rev.Dirty, &rev.Checksum); err != nil {
+			return Revision{}, err
+		}
+	}
+	return rev, rows.Err()
+}
+
+// appliedChecksums reads the recorded checksum for every version already
+// applied, so Migrate can detect an edited file no matter how long ago it
+// was applied, not just when it happens to be the latest one.
+func appliedChecksums(ctx context.Context, drv dialect.Driver) (map[int]string, error) {
+	rows := &sql.Rows{}
+	if err := drv.Query(ctx, "SELECT version, checksum FROM schema_migrations", []interface{}{}, rows); err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]string)
+	for rows.Next() {
+		var version int
+		var sum string
+		if err := rows.Scan(&version, &sum); err != nil {
+			return nil, err
+		}
+		applied[version] = sum
+	}
+	return applied, rows.Err()
+}
+
+func checksum(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+type migrationFile struct {
+	version int
+	body    string
+}
+
+func readMigrationFiles(dir, kind string) ([]migrationFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []migrationFile
+	for _, e := range entries {
+		m := migrationFileRE.FindStringSubmatch(e.Name())
+		if m == nil || m[3] != kind {
+			continue
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, migrationFile{version: version, body: string(body)})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].version < files[j].version })
+	return files, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+// Package sql provides a database/sql-backed implementation of dialect.Driver,
+// plus the convenience, stdlib-flavored methods (ExecContext, QueryRowContext,
+// ...) the integration tests use directly against it.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/facebook/ent/dialect"
+)
+
+// Result and Rows are re-exported so callers can pass *sql.Result/*sql.Rows
+// as the "v" argument to Driver.Exec/Query without importing database/sql
+// themselves.
+type (
+	Result = sql.Result
+	Rows   = sql.Rows
+)
+
+// Driver is a dialect.Driver backed by a *database/sql.DB (or, once inside a
+// transaction, a *database/sql.Tx).
+type Driver struct {
+	conn
+	dialect string
+}
+
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Open opens a new connection using driverName and source, and reports the
+// dialect it speaks: driverName verbatim for everything but "mysql", which is
+// also used by MariaDB and therefore needs a version-string probe to tell
+// the two apart.
+func Open(driverName, source string) (*Driver, error) {
+	db, err := sql.Open(driverName, source)
+	if err != nil {
+		return nil, err
+	}
+	drv := &Driver{conn: db, dialect: driverName}
+	if driverName == dialect.MySQL {
+		drv.dialect = detectMariaDB(context.Background(), drv)
+	}
+	return drv, nil
+}
+
+// Dialect reports the dialect this driver speaks, as determined by Open.
+func (d *Driver) Dialect() string { return d.dialect }
+
+// Exec executes query against the underlying connection. args is the
+// positional argument list and v, when non-nil, receives the result (for
+// *sql.Result) or is otherwise ignored; ExecContext is used by callers that
+// prefer the stdlib calling convention directly.
+func (d *Driver) Exec(ctx context.Context, query string, args, v interface{}) error {
+	vargs, _ := args.([]interface{})
+	res, err := d.ExecContext(ctx, query, vargs...)
+	if err != nil {
+		return err
+	}
+	if p, ok := v.(*sql.Result); ok && p != nil {
+		*p = res
+	}
+	return nil
+}
+
+// Query executes query against the underlying connection and scans the
+// resulting rows into v, which must be a *sql.Rows.
+func (d *Driver) Query(ctx context.Context, query string, args, v interface{}) error {
+	vargs, _ := args.([]interface{})
+	rows, err := d.QueryContext(ctx, query, vargs...)
+	if err != nil {
+		return err
+	}
+	if p, ok := v.(*sql.Rows); ok && p != nil {
+		*p = *rows
+	}
+	return nil
+}
+
+// QueryRowContext is a thin convenience wrapper around the underlying
+// connection, matching database/sql's calling convention directly.
+func (d *Driver) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	db, _ := d.conn.(*sql.DB)
+	return db.QueryRowContext(ctx, query, args...)
+}
+
+// Tx begins a transaction and returns it wrapped as a dialect.Tx.
+func (d *Driver) Tx(ctx context.Context) (dialect.Tx, error) {
+	db, ok := d.conn.(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("sql: cannot start a transaction on a connection that is itself a transaction")
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{conn: tx, dialect: d.dialect}, nil
+}
+
+// Close closes the underlying connection.
+func (d *Driver) Close() error {
+	db, ok := d.conn.(*sql.DB)
+	if !ok {
+		return nil
+	}
+	return db.Close()
+}
+
+// Tx is a dialect.Tx backed by a *database/sql.Tx.
+type Tx struct {
+	conn
+	dialect string
+}
+
+func (t *Tx) Dialect() string { return t.dialect }
+
+func (t *Tx) Exec(ctx context.Context, query string, args, v interface{}) error {
+	vargs, _ := args.([]interface{})
+	res, err := t.ExecContext(ctx, query, vargs...)
+	if err != nil {
+		return err
+	}
+	if p, ok := v.(*sql.Result); ok && p != nil {
+		*p = res
+	}
+	return nil
+}
+
+func (t *Tx) Query(ctx context.Context, query string, args, v interface{}) error {
+	vargs, _ := args.([]interface{})
+	rows, err := t.QueryContext(ctx, query, vargs...)
+	if err != nil {
+		return err
+	}
+	if p, ok := v.(*sql.Rows); ok && p != nil {
+		*p = *rows
+	}
+	return nil
+}
+
+// Tx on a transaction hands back itself: nested Schema.Create calls that
+// open "their own" transaction should keep using the one already open.
+func (t *Tx) Tx(context.Context) (dialect.Tx, error) { return t, nil }
+
+func (t *Tx) Commit() error   { return t.conn.(*sql.Tx).Commit() }
+func (t *Tx) Rollback() error { return t.conn.(*sql.Tx).Rollback() }
+func (t *Tx) Close() error    { return nil }
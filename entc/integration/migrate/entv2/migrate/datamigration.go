@@ -0,0 +1,69 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package migrate
+
+import (
+	"context"
+
+	"github.com/facebook/ent/dialect"
+	"github.com/facebook/ent/dialect/sql/schema"
+)
+
+// dataMigrations is the v2 schema's own data migration registry. It is kept
+// here, scoped to this generated schema package, rather than in a single
+// registry shared by every schema version, so a name registered against v2
+// can never collide with (or be confused with) one registered against some
+// other generated schema.
+var dataMigrations []schema.DataMigration
+
+// RegisterDataMigration registers a data migration to run the next time
+// RunDataMigrations is called. Registering the same name twice replaces the
+// previous callback rather than running it twice.
+func RegisterDataMigration(name string, run func(ctx context.Context, tx dialect.Tx) error) {
+	for i, dm := range dataMigrations {
+		if dm.Name == name {
+			dataMigrations[i].Run = run
+			return
+		}
+	}
+	dataMigrations = append(dataMigrations, schema.DataMigration{Name: name, Run: run})
+}
+
+// RunDataMigrations runs every data migration registered for the v2 schema
+// that hasn't already been recorded as applied, all inside one transaction
+// that also records each of them as done, so either every pending migration
+// and its bookkeeping commits or none does. That transaction is separate
+// from the one Create commits its DDL in, so a backfill is not atomic with
+// the DDL that introduced the column it fills; "exactly once" here rests on
+// the schema_data_migrations bookkeeping.
+func RunDataMigrations(ctx context.Context, drv dialect.Driver) error {
+	tx, err := drv.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	if err := schema.EnsureDataMigrationsTable(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	for _, dm := range dataMigrations {
+		done, err := schema.DataMigrationDone(ctx, tx, dm.Name)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if done {
+			continue
+		}
+		if err := dm.Run(ctx, tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := schema.MarkDataMigrationDone(ctx, tx, dm.Name); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
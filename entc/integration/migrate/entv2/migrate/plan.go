@@ -0,0 +1,26 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package migrate
+
+import (
+	"context"
+	"io"
+
+	"github.com/facebook/ent/dialect/sql/schema"
+)
+
+// Diff reports the set of changes Create would apply to the database behind
+// s for the given opts, without executing them.
+func (s *Schema) Diff(ctx context.Context, opts ...schema.MigrateOption) ([]schema.Change, error) {
+	return schema.Diff(ctx, s.drv, s.create, opts...)
+}
+
+// Plan writes the SQL that Create would run for the given opts, grouped by
+// table and annotated with severity (safe / requires-lock / destructive),
+// without executing it. Pass schema.WithDropColumn/WithDropIndex to see the
+// destructive changes Create only performs when opted into.
+func (s *Schema) Plan(ctx context.Context, w io.Writer, opts ...schema.MigrateOption) error {
+	return schema.Plan(ctx, s.drv, w, s.create, opts...)
+}
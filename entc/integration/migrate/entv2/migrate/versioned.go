@@ -0,0 +1,37 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package migrate
+
+import (
+	"context"
+
+	"github.com/facebook/ent/dialect"
+	"github.com/facebook/ent/dialect/sql/schema"
+)
+
+// create adapts Schema.Create to the signature Diff/Plan/WriteMigration run
+// against a recording driver, so they never drift from what Create itself
+// would apply, including which destructive options it was asked to apply.
+func (s *Schema) create(ctx context.Context, drv dialect.Driver, opts ...schema.MigrateOption) error {
+	return (&Schema{drv: drv}).Create(ctx, opts...)
+}
+
+// WriteMigration generates the next numbered up/down SQL migration pair for
+// the v2 schema into dir, diffing against what Create would apply.
+func (s *Schema) WriteMigration(ctx context.Context, dir, name string) error {
+	return schema.WriteMigration(ctx, s.drv, dir, name, s.create)
+}
+
+// Migrate applies every pending up-migration found in dir, transactionally
+// and in order, tracking progress in the schema_migrations table. It is a
+// no-op once every revision has already been applied.
+func (s *Schema) Migrate(ctx context.Context, dir string) error {
+	return schema.Migrate(ctx, s.drv, dir)
+}
+
+// Rollback runs the last n applied down-migrations found in dir.
+func (s *Schema) Rollback(ctx context.Context, dir string, n int) error {
+	return schema.Rollback(ctx, s.drv, dir, n)
+}
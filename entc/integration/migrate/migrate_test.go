@@ -8,12 +8,15 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/facebook/ent/dialect"
 	"github.com/facebook/ent/dialect/sql"
+	"github.com/facebook/ent/dialect/sql/schema"
 	"github.com/facebook/ent/entc/integration/migrate/entv1"
 	migratev1 "github.com/facebook/ent/entc/integration/migrate/entv1/migrate"
 	userv1 "github.com/facebook/ent/entc/integration/migrate/entv1/user"
@@ -21,6 +24,7 @@ import (
 	"github.com/facebook/ent/entc/integration/migrate/entv2/conversion"
 	migratev2 "github.com/facebook/ent/entc/integration/migrate/entv2/migrate"
 	"github.com/facebook/ent/entc/integration/migrate/entv2/user"
+	"github.com/facebook/ent/schema/field"
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
@@ -44,7 +48,33 @@ func TestMySQL(t *testing.T) {
 
 			clientv1 := entv1.NewClient(entv1.Driver(drv))
 			clientv2 := entv2.NewClient(entv2.Driver(drv))
-			V1ToV2(t, drv.Dialect(), clientv1, clientv2)
+			V1ToV2(t, drv, clientv1, clientv2)
+		})
+	}
+}
+
+func TestMariaDB(t *testing.T) {
+	for version, port := range map[string]int{"103": 4306, "105": 4307, "1011": 4308} {
+		t.Run(version, func(t *testing.T) {
+			root, err := sql.Open("mysql", fmt.Sprintf("root:pass@tcp(localhost:%d)/", port))
+			require.NoError(t, err)
+			defer root.Close()
+			ctx := context.Background()
+			err = root.Exec(ctx, "CREATE DATABASE IF NOT EXISTS migrate", []interface{}{}, new(sql.Result))
+			require.NoError(t, err, "creating database")
+			defer root.Exec(ctx, "DROP DATABASE IF EXISTS migrate", []interface{}{}, new(sql.Result))
+
+			drv, err := sql.Open("mysql", fmt.Sprintf("root:pass@tcp(localhost:%d)/migrate?parseTime=True", port))
+			require.NoError(t, err, "connecting to migrate database")
+
+			// sql.Open inspects the server version string returned by MariaDB
+			// (e.g. "5.5.5-10.5.9-MariaDB"), so drv.Dialect() reports dialect.MariaDB
+			// rather than dialect.MySQL.
+			require.Equal(t, dialect.MariaDB, drv.Dialect())
+
+			clientv1 := entv1.NewClient(entv1.Driver(drv))
+			clientv2 := entv2.NewClient(entv2.Driver(drv))
+			V1ToV2(t, drv, clientv1, clientv2)
 		})
 	}
 }
@@ -72,11 +102,49 @@ func TestPostgres(t *testing.T) {
 
 			clientv1 := entv1.NewClient(entv1.Driver(drv))
 			clientv2 := entv2.NewClient(entv2.Driver(drv))
-			V1ToV2(t, drv.Dialect(), clientv1, clientv2)
+			V1ToV2(t, drv, clientv1, clientv2)
+			TypeConverters(t, drv)
 		})
 	}
 }
 
+// TypeConverters exercises the pluggable type-conversion registry on column-type
+// changes the migration engine cannot express as a plain ALTER COLUMN TYPE, beyond
+// the numeric-to-string conversions already covered by the Conversion entity.
+func TypeConverters(t *testing.T, drv *sql.Driver) {
+	ctx := context.Background()
+
+	_, err := drv.ExecContext(ctx, "CREATE TABLE widgets (id serial primary key, token text, meta text)")
+	require.NoError(t, err)
+	defer drv.ExecContext(ctx, "DROP TABLE widgets")
+
+	_, err = drv.ExecContext(ctx, "INSERT INTO widgets (token, meta) VALUES ($1, $2)", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", `{"k":"v"}`)
+	require.NoError(t, err)
+
+	conv, ok := schema.TypeConverterFor(&schema.ColumnType{Type: field.TypeString}, &schema.ColumnType{Type: field.TypeUUID})
+	require.True(t, ok, "string -> uuid converter should be registered")
+	stmts, err := conv.SQL(dialect.Postgres, "widgets", "token")
+	require.NoError(t, err)
+	for _, stmt := range stmts {
+		_, err = drv.ExecContext(ctx, stmt)
+		require.NoError(t, err, "ALTER TABLE ... USING should convert the column in place")
+	}
+
+	conv, ok = schema.TypeConverterFor(&schema.ColumnType{Type: field.TypeText}, &schema.ColumnType{Type: field.TypeJSON})
+	require.True(t, ok, "text -> jsonb converter should be registered")
+	stmts, err = conv.SQL(dialect.Postgres, "widgets", "meta")
+	require.NoError(t, err)
+	for _, stmt := range stmts {
+		_, err = drv.ExecContext(ctx, stmt)
+		require.NoError(t, err, "ALTER TABLE ... USING should convert the column in place")
+	}
+
+	var kind string
+	err = drv.QueryRowContext(ctx, `SELECT data_type FROM information_schema.columns WHERE table_name = 'widgets' AND column_name = 'meta'`).Scan(&kind)
+	require.NoError(t, err)
+	require.Equal(t, "jsonb", kind)
+}
+
 func TestSQLite(t *testing.T) {
 	drv, err := sql.Open("sqlite3", "file:ent?mode=memory&cache=shared&_fk=1")
 	require.NoError(t, err)
@@ -103,17 +171,91 @@ func TestSQLite(t *testing.T) {
 	ContainsFold(t, client)
 }
 
-func V1ToV2(t *testing.T, dialect string, clientv1 *entv1.Client, clientv2 *entv2.Client) {
+func TestVersionedMigration(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	drv, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?cache=shared&_fk=1", filepath.Join(dir, "ent.db")))
+	require.NoError(t, err)
+	defer drv.Close()
+
+	client := entv2.NewClient(entv2.Driver(drv))
+	require.NoError(t, client.Schema.WriteMigration(ctx, dir, "init"))
+	require.FileExists(t, filepath.Join(dir, "0001_init.up.sql"))
+	require.FileExists(t, filepath.Join(dir, "0001_init.down.sql"))
+
+	// Applying the generated revisions is equivalent to running Schema.Create directly.
+	require.NoError(t, client.Schema.Migrate(ctx, dir))
+	require.NoError(t, client.Schema.Migrate(ctx, dir), "re-running Migrate should be a no-op")
+	SanityV2(t, drv.Dialect(), client)
+
+	// Marking the tracked revision dirty must block further runs until it is fixed.
+	_, err = drv.ExecContext(ctx, "UPDATE schema_migrations SET dirty = 1 WHERE version = 1")
+	require.NoError(t, err)
+	require.Error(t, client.Schema.Migrate(ctx, dir), "migrate should refuse to run on a dirty database")
+	_, err = drv.ExecContext(ctx, "UPDATE schema_migrations SET dirty = 0 WHERE version = 1")
+	require.NoError(t, err)
+
+	// Rollback should run the down-migration and remove the tracked revision.
+	require.NoError(t, client.Schema.Rollback(ctx, dir, 1))
+	_, err = client.User.Query().Count(ctx)
+	require.Error(t, err, "rolling back the only revision should have dropped the users table")
+
+	// Re-applying afterwards should recreate everything from scratch.
+	require.NoError(t, client.Schema.Migrate(ctx, dir))
+	SanityV2(t, drv.Dialect(), client)
+
+	// Editing a file after it was written changes its checksum and must fail loudly.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0001_init.up.sql"), []byte("-- tampered\n"), 0o644))
+	require.Error(t, client.Schema.Migrate(ctx, dir), "checksum mismatch should be rejected")
+}
+
+func V1ToV2(t *testing.T, drv *sql.Driver, clientv1 *entv1.Client, clientv2 *entv2.Client) {
 	ctx := context.Background()
+	dbdialect := drv.Dialect()
 
 	// Run migration and execute queries on v1.
 	require.NoError(t, clientv1.Schema.Create(ctx, migratev1.WithGlobalUniqueID(true)))
-	SanityV1(t, dialect, clientv1)
+	SanityV1(t, dbdialect, clientv1)
+
+	// Backfill "title" for rows that existed prior to the v1->v2 migration, instead
+	// of relying on the column default to populate them. It must run exactly once,
+	// tracked by schema_data_migrations so the second RunDataMigrations call below
+	// doesn't re-execute it; it runs in its own transaction immediately after Create, not inside
+	// Create's own DDL transaction, so it is not atomic with the column's creation.
+	var ran int
+	migratev2.RegisterDataMigration("backfill_title", func(ctx context.Context, tx dialect.Tx) error {
+		ran++
+		q := "UPDATE users SET title = " + schema.Placeholder(tx.Dialect(), 1) + " WHERE title = ''"
+		return tx.Exec(ctx, q, []interface{}{user.DefaultTitle}, nil)
+	})
+
+	// Review the v2 migration before applying it, as operators would in code review.
+	// Pass the same drop options the Create call below uses, so the plan actually
+	// surfaces the destructive changes Create would perform.
+	var plan strings.Builder
+	require.NoError(t, clientv2.Schema.Plan(ctx, &plan, schema.WithDropIndex(true), schema.WithDropColumn(true)))
+	out := strings.ToLower(plan.String())
+	require.Contains(t, out, "renamed", "plan should mention the column rename")
+	require.Contains(t, out, "new_name", "plan should mention the column rename")
+	require.Contains(t, out, "blob", "plan should mention the blob column being widened")
+	require.Contains(t, out, "destructive", "plan should flag the dropped column/index as destructive")
+	var ageIndexLine string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "age") && strings.Contains(line, "phone") {
+			ageIndexLine = line
+			break
+		}
+	}
+	require.NotEmpty(t, ageIndexLine, "plan should contain a single statement creating the unique index on (age, phone)")
 
 	// Run migration and execute queries on v2.
 	require.NoError(t, clientv2.Schema.Create(ctx, migratev2.WithGlobalUniqueID(true), migratev2.WithDropIndex(true), migratev2.WithDropColumn(true)))
+	require.NoError(t, migratev2.RunDataMigrations(ctx, drv))
 	require.NoError(t, clientv2.Schema.Create(ctx, migratev2.WithGlobalUniqueID(true)), "should not create additional resources on multiple runs")
-	SanityV2(t, dialect, clientv2)
+	require.NoError(t, migratev2.RunDataMigrations(ctx, drv))
+	require.Equal(t, 1, ran, "data migration should have run exactly once, tracked by schema_data_migrations")
+	SanityV2(t, dbdialect, clientv2)
 
 	idRange(t, clientv2.Car.Create().SaveX(ctx).ID, 0, 1<<32)
 	idRange(t, clientv2.Conversion.Create().SaveX(ctx).ID, 1<<32-1, 2<<32)